@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gravitational/teleport-plugins/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// AdminServer serves the plugin's operational endpoints (/reconcile,
+// /metrics) on their own listen address, separate from WebhookServer's
+// internet-facing PagerDuty callback port. Those endpoints can trigger
+// real PagerDuty API calls and SetRequestState transitions and, unlike
+// /actions/*, are not covered by the webhook signature check. App.run uses
+// AdminServer only when [http].admin_listen_addr is set; otherwise it
+// serves those endpoints on the public webhook port and logs a warning.
+type AdminServer struct {
+	listenAddr string
+	mux        *http.ServeMux
+	httpServer *http.Server
+
+	job utils.ServiceJob
+}
+
+// NewAdminServer creates an AdminServer listening on listenAddr.
+func NewAdminServer(listenAddr string) *AdminServer {
+	s := &AdminServer{
+		listenAddr: listenAddr,
+		mux:        http.NewServeMux(),
+	}
+	s.httpServer = &http.Server{Addr: listenAddr, Handler: s.mux}
+	s.job = utils.NewServiceJob(s.run)
+	return s
+}
+
+// HandleFunc registers a handler on the server's mux.
+func (s *AdminServer) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// ServiceJob returns the utils.ServiceJob that serves the admin server,
+// for the caller to spawn and wait on.
+func (s *AdminServer) ServiceJob() utils.ServiceJob {
+	return s.job
+}
+
+// run serves the admin server until ctx is done, reporting ready once the
+// listener is up.
+func (s *AdminServer) run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.listenAddr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	s.job.SetReady(true)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.httpServer.Serve(ln)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return trace.Wrap(s.httpServer.Close())
+	case err := <-errCh:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+}
@@ -2,18 +2,31 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/gravitational/teleport-plugins/access"
+	"github.com/gravitational/teleport-plugins/access/pagerduty/events"
 	"github.com/gravitational/teleport-plugins/utils"
 
 	"github.com/gravitational/trace"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	log "github.com/sirupsen/logrus"
 )
 
+// healthCheckInterval is how often the background PagerDuty health check
+// runs once the plugin is up.
+const healthCheckInterval = 30 * time.Second
+
+// healthFreshnessDefault is how stale LastHealthCheck may be before
+// /healthz starts failing, when [health].freshness is unset.
+const healthFreshnessDefault = time.Minute
+
 // App contains global application state.
 type App struct {
 	conf Config
@@ -21,6 +34,11 @@ type App struct {
 	accessClient access.Client
 	bot          *Bot
 	webhookSrv   *WebhookServer
+	adminSrv     *AdminServer
+	router       *ServiceRouter
+	reconciler   *Reconciler
+	events       events.EventSink
+	watcherJob   utils.ServiceJob
 	mainJob      utils.ServiceJob
 
 	*utils.Process
@@ -61,7 +79,14 @@ func (a *App) run(ctx context.Context) (err error) {
 		return
 	}
 
-	a.bot = NewBot(a.conf.Pagerduty, a.webhookSrv)
+	a.events = a.buildEventSink()
+
+	a.bot = NewBot(a.conf.Pagerduty, a.conf.AutoApproval, a.events, a.webhookSrv)
+
+	a.router, err = NewServiceRouter(a.conf.ServiceRoutes)
+	if err != nil {
+		return
+	}
 
 	tlsConf, err := access.LoadTLSConfig(
 		a.conf.Teleport.ClientCrt,
@@ -87,6 +112,18 @@ func (a *App) run(ctx context.Context) (err error) {
 		return
 	}
 
+	a.reconciler = NewReconciler(a.accessClient, a.bot, a.router, a.events, a.conf.Reconciler.Interval)
+	if a.conf.HTTP.AdminListenAddr != "" {
+		a.adminSrv = NewAdminServer(a.conf.HTTP.AdminListenAddr)
+		a.adminSrv.HandleFunc("/reconcile", a.reconciler.AdminHandler)
+		a.adminSrv.HandleFunc("/metrics", promhttp.Handler().ServeHTTP)
+	} else {
+		log.Warning("[http].admin_listen_addr is not set: serving /reconcile and /metrics on the public webhook port")
+		a.webhookSrv.HandleFunc("/reconcile", a.reconciler.AdminHandler)
+		a.webhookSrv.HandleFunc("/metrics", promhttp.Handler().ServeHTTP)
+	}
+	a.webhookSrv.HandleFunc("/healthz", a.healthzHandler)
+
 	log.Debug("Starting PagerDuty API health check...")
 	if err = a.bot.HealthCheck(ctx); err != nil {
 		log.WithError(err).Error("PagerDuty API health check failed")
@@ -105,8 +142,19 @@ func (a *App) run(ctx context.Context) (err error) {
 		return
 	}
 
+	var adminJob utils.ServiceJob
+	adminOk := true
+	if a.adminSrv != nil {
+		adminJob = a.adminSrv.ServiceJob()
+		a.SpawnCriticalJob(adminJob)
+		adminOk, err = adminJob.WaitReady(ctx)
+		if err != nil {
+			return
+		}
+	}
+
 	log.Debug("Setting up the webhook extensions")
-	if err = a.bot.Setup(ctx); err != nil {
+	if err = a.bot.Setup(ctx, a.routeTargets()); err != nil {
 		log.WithError(err).Error("Failed to set up webhook extensions")
 		return
 	}
@@ -117,20 +165,131 @@ func (a *App) run(ctx context.Context) (err error) {
 		access.Filter{State: access.StatePending},
 		a.onWatcherEvent,
 	)
+	a.watcherJob = watcherJob
 	a.SpawnCriticalJob(watcherJob)
 	watcherOk, err := watcherJob.WaitReady(ctx)
 	if err != nil {
 		return
 	}
 
-	a.mainJob.SetReady(httpOk && watcherOk)
+	go func() {
+		if err := a.reconciler.Run(ctx); err != nil {
+			log.WithError(err).Error("Reconciler stopped")
+		}
+	}()
+	go a.runHealthChecks(ctx)
+
+	a.mainJob.SetReady(httpOk && watcherOk && adminOk)
 
 	<-httpJob.Done()
 	<-watcherJob.Done()
+	if adminJob != nil {
+		<-adminJob.Done()
+		return trace.NewAggregate(httpJob.Err(), watcherJob.Err(), adminJob.Err())
+	}
 
 	return trace.NewAggregate(httpJob.Err(), watcherJob.Err())
 }
 
+// runHealthChecks re-runs Bot.HealthCheck on healthCheckInterval until ctx is
+// done, keeping Bot.LastHealthCheck fresh for /healthz.
+func (a *App) runHealthChecks(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.bot.HealthCheck(ctx); err != nil {
+				log.WithError(err).Warn("Periodic PagerDuty health check failed")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// healthzHandler reports the plugin healthy only once both the PagerDuty API
+// and the Teleport watcher are known-good, so it is safe to use for
+// Kubernetes liveness/readiness probes.
+func (a *App) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	freshness := a.conf.Health.Freshness
+	if freshness <= 0 {
+		freshness = healthFreshnessDefault
+	}
+	if age := time.Since(a.bot.LastHealthCheck()); age > freshness {
+		http.Error(w, "pagerduty health check is stale", http.StatusServiceUnavailable)
+		return
+	}
+	if a.watcherJob == nil || !a.watcherJob.IsReady() {
+		http.Error(w, "teleport watcher is not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+// buildEventSink assembles the configured event sinks (file/webhook/stdout)
+// behind a single bounded FanOut, from the [events] TOML config. With
+// nothing configured it falls back to a no-op sink.
+func (a *App) buildEventSink() events.EventSink {
+	var sinks []events.EventSink
+
+	if path := a.conf.Events.File.Path; path != "" {
+		sink, err := events.NewFileSink(path)
+		if err != nil {
+			log.WithError(err).WithField("path", path).Error("Failed to open event file sink, skipping it")
+		} else {
+			sinks = append(sinks, sink)
+		}
+	}
+	if url := a.conf.Events.Webhook.URL; url != "" {
+		sinks = append(sinks, events.NewWebhookSink(url, a.conf.Events.Webhook.Secret))
+	}
+	if a.conf.Events.Stdout {
+		sinks = append(sinks, events.NewStdoutSink(os.Stdout))
+	}
+
+	if len(sinks) == 0 {
+		return events.NoopSink{}
+	}
+
+	queueSize := a.conf.Events.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	return events.NewFanOut(queueSize, sinks...)
+}
+
+// routeTargets returns every PagerDuty service that should have the
+// approve/deny custom actions installed on it: every service_route plus the
+// bot's own default service.
+func (a *App) routeTargets() []RouteTarget {
+	targets := a.router.Targets()
+	targets = append(targets, a.bot.defaultTarget())
+	return targets
+}
+
+// resolveTarget picks the RouteTarget for an access request's roles, falling
+// back to the bot's own default service when no service_route matches.
+func (a *App) resolveTarget(reqRoles []string) RouteTarget {
+	if target, ok := a.router.Route(reqRoles); ok {
+		return target
+	}
+	return a.bot.defaultTarget()
+}
+
+// resolveTargetForService picks the RouteTarget that a previously created
+// incident on serviceID belongs to, falling back to the bot's own default
+// service when no service_route matches. Used when only PagerdutyData (and
+// not the original request's roles) is available, e.g. in webhook
+// callbacks and when expiring a deleted request.
+func (a *App) resolveTargetForService(serviceID string) RouteTarget {
+	if target, ok := a.router.TargetForService(serviceID); ok {
+		return target
+	}
+	return a.bot.defaultTarget()
+}
+
 func (a *App) checkTeleportVersion(ctx context.Context) error {
 	log.Debug("Checking Teleport server version")
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -177,12 +336,26 @@ func (a *App) onWatcherEvent(ctx context.Context, event access.Event) error {
 	}
 }
 
-func (a *App) onPagerdutyAction(ctx context.Context, action WebhookAction) error {
-	log := log.WithFields(logFields{
+func (a *App) onPagerdutyAction(ctx context.Context, action WebhookAction) (err error) {
+	outcome := "success"
+	defer func() {
+		if err != nil {
+			outcome = "error"
+		}
+		metricWebhookActions.WithLabelValues(action.Name, outcome).Inc()
+	}()
+
+	log := log.WithFields(log.Fields{
 		"pd_http_id": action.HTTPRequestID,
 		"pd_msg_id":  action.MessageID,
 	})
 
+	if !verifyWebhookSignature(a.conf.Pagerduty.WebhookSigningSecrets, action.Signature, action.RawBody) {
+		metricSignatureFailures.Inc()
+		log.Warning("Rejecting webhook action with invalid or missing signature")
+		return trace.AccessDenied("invalid webhook signature")
+	}
+
 	if action.Event != "incident.custom" {
 		log.Debugf("Got %q event, ignoring", action.Event)
 		return nil
@@ -223,15 +396,18 @@ func (a *App) onPagerdutyAction(ctx context.Context, action WebhookAction) error
 	var (
 		reqState   access.State
 		resolution string
+		kind       events.Kind
 	)
 
 	switch action.Name {
 	case pdApproveAction:
 		reqState = access.StateApproved
 		resolution = "approved"
+		kind = events.KindRequestApproved
 	case pdDenyAction:
 		reqState = access.StateDenied
 		resolution = "denied"
+		kind = events.KindRequestDenied
 	default:
 		return trace.BadParameter("unknown action: %q", action.Name)
 	}
@@ -241,7 +417,8 @@ func (a *App) onPagerdutyAction(ctx context.Context, action WebhookAction) error
 	}
 	log.Infof("PagerDuty user %s the request", resolution)
 
-	if err := a.bot.ResolveIncident(ctx, reqID, pluginData.PagerdutyData, resolution); err != nil {
+	target := a.resolveTargetForService(pluginData.PagerdutyData.ServiceID)
+	if err := a.bot.ResolveIncident(ctx, reqID, pluginData.PagerdutyData, target, resolution, kind, "pagerduty-webhook"); err != nil {
 		return trace.Wrap(err)
 	}
 	log.Infof("Incident %q has been resolved", action.IncidentID)
@@ -252,21 +429,131 @@ func (a *App) onPagerdutyAction(ctx context.Context, action WebhookAction) error
 func (a *App) onPendingRequest(ctx context.Context, req access.Request) error {
 	reqData := RequestData{User: req.User, Roles: req.Roles, Created: req.Created}
 
-	pdData, err := a.bot.CreateIncident(ctx, req.ID, reqData)
+	a.publishEvent(ctx, events.Event{
+		Kind:      events.KindRequestPending,
+		RequestID: req.ID,
+		User:      req.User,
+		Roles:     req.Roles,
+		Actor:     req.User,
+	})
+
+	approved, err := a.tryAutoApprove(ctx, req, reqData)
+	// Check approved before err: tryAutoApprove can return (true, err)
+	// when the approval itself went through but a later, non-critical
+	// step (recording the informational incident) failed, and that case
+	// must not fall through to creating a second, manual incident for an
+	// already-approved request.
+	if approved {
+		if err != nil {
+			log.WithError(err).WithField("request_id", req.ID).Warning(
+				"Auto-approved request but failed to record the informational incident")
+		}
+		return nil
+	}
+	if err != nil {
+		log.WithError(err).WithField("request_id", req.ID).Warning(
+			"On-call auto-approval check failed, falling back to manual incident flow")
+	}
+
+	target := a.resolveTarget(req.Roles)
+	pdData, err := a.bot.CreateIncident(ctx, req.ID, reqData, target)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
-	log.WithFields(logFields{
+	log.WithFields(log.Fields{
 		"request_id":     req.ID,
 		"pd_incident_id": pdData.ID,
 	}).Info("PagerDuty incident created")
 
+	a.publishEvent(ctx, events.Event{
+		Kind:       events.KindIncidentCreated,
+		RequestID:  req.ID,
+		User:       req.User,
+		Roles:      req.Roles,
+		IncidentID: pdData.ID,
+	})
+
 	err = a.setPluginData(ctx, req.ID, PluginData{reqData, pdData})
 
 	return trace.Wrap(err)
 }
 
+// publishEvent stamps event.Time and publishes it, logging (but not
+// failing the caller) if the sink rejects it.
+func (a *App) publishEvent(ctx context.Context, event events.Event) {
+	event.Time = time.Now()
+	if err := a.events.Publish(ctx, event); err != nil {
+		log.WithError(err).WithField("kind", event.Kind).Warn("Failed to publish lifecycle event")
+	}
+}
+
+// tryAutoApprove approves req on the spot, without paging anyone, when the
+// requesting user is currently on-call per the [auto_approval] config. It
+// returns false, nil when auto-approval does not apply so the caller should
+// proceed with the normal incident flow.
+func (a *App) tryAutoApprove(ctx context.Context, req access.Request, reqData RequestData) (bool, error) {
+	conf := a.conf.AutoApproval
+	if len(conf.ScheduleIDs) == 0 && len(conf.EscalationPolicyIDs) == 0 {
+		return false, nil
+	}
+	if len(conf.AllowedRoles) > 0 && !rolesIntersect(conf.AllowedRoles, req.Roles) {
+		return false, nil
+	}
+
+	onCall, err := a.bot.IsUserOnCall(ctx, req.User)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	if !onCall {
+		return false, nil
+	}
+
+	if err := a.accessClient.SetRequestState(ctx, req.ID, access.StateApproved); err != nil {
+		return false, trace.Wrap(err)
+	}
+	log.WithField("request_id", req.ID).WithField("user", req.User).Info(
+		"Auto-approved access request: user is on-call")
+
+	if !conf.CreateInformationalIncident {
+		a.publishEvent(ctx, events.Event{
+			Kind:      events.KindRequestApproved,
+			RequestID: req.ID,
+			User:      req.User,
+			Roles:     req.Roles,
+			Actor:     "auto-approval",
+		})
+		return true, nil
+	}
+
+	target := a.resolveTarget(req.Roles)
+	pdData, err := a.bot.CreateIncident(ctx, req.ID, reqData, target)
+	if err != nil {
+		return true, trace.Wrap(err)
+	}
+	if err := a.bot.ResolveIncident(ctx, req.ID, pdData, target, "auto-approved (user is on-call)",
+		events.KindRequestApproved, "auto-approval"); err != nil {
+		return true, trace.Wrap(err)
+	}
+	if err := a.setPluginData(ctx, req.ID, PluginData{reqData, pdData}); err != nil {
+		return true, trace.Wrap(err)
+	}
+
+	return true, nil
+}
+
+// rolesIntersect reports whether any role in reqRoles appears in allowed.
+func rolesIntersect(allowed, reqRoles []string) bool {
+	for _, a := range allowed {
+		for _, r := range reqRoles {
+			if a == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (a *App) onDeletedRequest(ctx context.Context, req access.Request) error {
 	reqID := req.ID // This is the only available field
 	pluginData, err := a.getPluginData(ctx, reqID)
@@ -278,7 +565,9 @@ func (a *App) onDeletedRequest(ctx context.Context, req access.Request) error {
 		return trace.Wrap(err)
 	}
 
-	if err := a.bot.ResolveIncident(ctx, reqID, pluginData.PagerdutyData, "expired"); err != nil {
+	target := a.resolveTargetForService(pluginData.PagerdutyData.ServiceID)
+	if err := a.bot.ResolveIncident(ctx, reqID, pluginData.PagerdutyData, target, "expired",
+		events.KindRequestExpired, "teleport-watcher"); err != nil {
 		return trace.Wrap(err)
 	}
 
@@ -4,14 +4,19 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
 	pd "github.com/PagerDuty/go-pagerduty"
 
+	"github.com/gravitational/teleport-plugins/access/pagerduty/events"
+
 	"github.com/gravitational/trace"
-	// log "github.com/sirupsen/logrus"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -39,6 +44,26 @@ func init() {
 	}
 }
 
+// AutoApprovalConfig configures on-call-aware auto-approval of access
+// requests, see [auto_approval] in the plugin's TOML config.
+type AutoApprovalConfig struct {
+	// ScheduleIDs and EscalationPolicyIDs are the PagerDuty on-call
+	// schedules/escalation policies checked for the requesting user.
+	ScheduleIDs         []string `toml:"schedule_ids"`
+	EscalationPolicyIDs []string `toml:"escalation_policy_ids"`
+	// AllowedRoles restricts auto-approval to requests for these roles only.
+	// If empty, any role is eligible.
+	AllowedRoles []string `toml:"allowed_roles"`
+	// Lookahead is how far into the future an on-call shift may start and
+	// still count as "currently on-call" for approval purposes.
+	Lookahead time.Duration `toml:"lookahead"`
+	// CreateInformationalIncident, when true, still creates a PagerDuty
+	// incident for an auto-approved request and immediately resolves it
+	// with a note explaining the auto-approval, instead of skipping
+	// incident creation entirely.
+	CreateInformationalIncident bool `toml:"create_informational_incident"`
+}
+
 // Bot is a wrapper around pd.Client that works with access.Request
 type Bot struct {
 	httpClient  *http.Client
@@ -48,7 +73,13 @@ type Bot struct {
 	from        string
 	serviceID   string
 
+	autoApproval AutoApprovalConfig
+	events       events.EventSink
+
 	clusterName string
+
+	healthMu        sync.Mutex
+	lastHealthCheck time.Time
 }
 
 type HTTPClientImpl func(*http.Request) (*http.Response, error)
@@ -57,7 +88,7 @@ func (h HTTPClientImpl) Do(req *http.Request) (*http.Response, error) {
 	return h(req)
 }
 
-func NewBot(conf PagerdutyConfig, server *WebhookServer) *Bot {
+func NewBot(conf PagerdutyConfig, autoApproval AutoApprovalConfig, sink events.EventSink, server *WebhookServer) *Bot {
 	httpClient := &http.Client{
 		Timeout: pdHTTPTimeout,
 		Transport: &http.Transport{
@@ -66,12 +97,14 @@ func NewBot(conf PagerdutyConfig, server *WebhookServer) *Bot {
 		},
 	}
 	return &Bot{
-		httpClient:  httpClient,
-		server:      server,
-		apiEndpoint: conf.APIEndpoint,
-		apiKey:      conf.APIKey,
-		from:        conf.UserEmail,
-		serviceID:   conf.ServiceID,
+		httpClient:   httpClient,
+		server:       server,
+		apiEndpoint:  conf.APIEndpoint,
+		apiKey:       conf.APIKey,
+		from:         conf.UserEmail,
+		serviceID:    conf.ServiceID,
+		autoApproval: autoApproval,
+		events:       sink,
 	}
 }
 
@@ -82,12 +115,61 @@ func (b *Bot) NewClient(ctx context.Context) *pd.Client {
 		clientOpts = append(clientOpts, pd.WithAPIEndpoint(b.apiEndpoint))
 	}
 	client := pd.NewClient(b.apiKey, clientOpts...)
-	client.HTTPClient = HTTPClientImpl(func(r *http.Request) (*http.Response, error) {
+	client.HTTPClient = HTTPClientImpl(observeAPICall(func(r *http.Request) (*http.Response, error) {
 		return b.httpClient.Do(r.WithContext(ctx))
-	})
+	}))
 	return client
 }
 
+// observeAPICall wraps an HTTP round-trip function with the Prometheus
+// latency and error-rate observations used for PagerDuty API calls.
+func observeAPICall(do func(*http.Request) (*http.Response, error)) func(*http.Request) (*http.Response, error) {
+	return func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := do(r)
+		endpoint := normalizeAPIPath(r.URL.Path)
+		metricPagerdutyLatency.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		if err != nil {
+			metricPagerdutyErrors.WithLabelValues("error").Inc()
+			return resp, err
+		}
+		if resp.StatusCode >= 400 {
+			metricPagerdutyErrors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+		}
+		return resp, nil
+	}
+}
+
+// apiPathStaticSegments lists the fixed, non-ID path segments used by the
+// PagerDuty API calls this plugin makes, see normalizeAPIPath.
+var apiPathStaticSegments = map[string]bool{
+	"incidents":             true,
+	"notes":                 true,
+	"extension_schemas":     true,
+	"extensions":            true,
+	"services":              true,
+	"users":                 true,
+	"oncalls":               true,
+	"schedules":             true,
+	"webhook_subscriptions": true,
+}
+
+// normalizeAPIPath collapses dynamic PagerDuty resource IDs out of path
+// (e.g. "/incidents/PXXXXXXX/notes" becomes "/incidents/{id}/notes") so
+// the metricPagerdutyLatency/metricPagerdutyErrors "endpoint" label stays
+// a small, fixed set of route templates instead of growing one time
+// series per incident/extension/user/service ID ever seen.
+func normalizeAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == "" || apiPathStaticSegments[segment] {
+			continue
+		}
+		segments[i] = "{id}"
+	}
+	return strings.Join(segments, "/")
+}
+
 func (b *Bot) HealthCheck(ctx context.Context) error {
 	client := b.NewClient(ctx)
 
@@ -95,10 +177,25 @@ func (b *Bot) HealthCheck(ctx context.Context) error {
 		return trace.Wrap(err, "failed to fetch pagerduty service info: %v", err)
 	}
 
+	b.healthMu.Lock()
+	b.lastHealthCheck = time.Now()
+	b.healthMu.Unlock()
+
 	return nil
 }
 
-func (b *Bot) Setup(ctx context.Context) error {
+// LastHealthCheck returns the time of the most recent successful
+// HealthCheck, used by WebhookServer's /healthz handler to judge freshness.
+func (b *Bot) LastHealthCheck() time.Time {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+	return b.lastHealthCheck
+}
+
+// Setup installs the approve/deny custom actions on every service in
+// targets. It does not add b's own default service itself; App.routeTargets
+// passes that service together with every ServiceRouter service.
+func (b *Bot) Setup(ctx context.Context, targets []RouteTarget) error {
 	client := b.NewClient(ctx)
 
 	var more bool
@@ -129,6 +226,19 @@ func (b *Bot) Setup(ctx context.Context) error {
 		return trace.NotFound(`failed to find "Custom Incident Action" extension type`)
 	}
 
+	for _, target := range targets {
+		if err := b.setupService(client, webhookSchemaID, target); err != nil {
+			return trace.Wrap(err, "setting up service %q", target.ServiceID)
+		}
+	}
+
+	return nil
+}
+
+func (b *Bot) setupService(client *pd.Client, webhookSchemaID string, target RouteTarget) error {
+	var more bool
+	var offset uint
+
 	var approveExtID, denyExtID string
 	for offset, more = 0, true; (approveExtID == "" || denyExtID == "") && more; {
 		extResp, err := client.ListExtensions(pd.ListExtensionOptions{
@@ -136,7 +246,7 @@ func (b *Bot) Setup(ctx context.Context) error {
 				Offset: offset,
 				Limit:  pdListLimit,
 			},
-			ExtensionObjectID: b.serviceID,
+			ExtensionObjectID: target.ServiceID,
 			ExtensionSchemaID: webhookSchemaID,
 		})
 		if err != nil {
@@ -156,17 +266,17 @@ func (b *Bot) Setup(ctx context.Context) error {
 		offset += pdListLimit
 	}
 
-	if err := b.setupCustomAction(client, approveExtID, webhookSchemaID, pdApproveAction, pdApproveActionLabel); err != nil {
+	if err := b.setupCustomAction(client, approveExtID, webhookSchemaID, pdApproveAction, pdApproveActionLabel, target); err != nil {
 		return err
 	}
-	if err := b.setupCustomAction(client, denyExtID, webhookSchemaID, pdDenyAction, pdDenyActionLabel); err != nil {
+	if err := b.setupCustomAction(client, denyExtID, webhookSchemaID, pdDenyAction, pdDenyActionLabel, target); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (b *Bot) setupCustomAction(client *pd.Client, extensionID, schemaID, actionName, actionLabel string) error {
+func (b *Bot) setupCustomAction(client *pd.Client, extensionID, schemaID, actionName, actionLabel string, target RouteTarget) error {
 	actionURL := b.server.ActionURL(actionName)
 	ext := &pd.Extension{
 		Name:        actionLabel,
@@ -178,7 +288,7 @@ func (b *Bot) setupCustomAction(client *pd.Client, extensionID, schemaID, action
 		ExtensionObjects: []pd.APIObject{
 			pd.APIObject{
 				Type: "service_reference",
-				ID:   b.serviceID,
+				ID:   target.ServiceID,
 			},
 		},
 	}
@@ -190,7 +300,13 @@ func (b *Bot) setupCustomAction(client *pd.Client, extensionID, schemaID, action
 	return trace.Wrap(err)
 }
 
-func (b *Bot) CreateIncident(ctx context.Context, reqID string, reqData RequestData) (PagerdutyData, error) {
+// defaultTarget returns the RouteTarget for b's own configured service,
+// used when no ServiceRouter match applies.
+func (b *Bot) defaultTarget() RouteTarget {
+	return RouteTarget{ServiceID: b.serviceID, From: b.from}
+}
+
+func (b *Bot) CreateIncident(ctx context.Context, reqID string, reqData RequestData, target RouteTarget) (PagerdutyData, error) {
 	client := b.NewClient(ctx)
 
 	body, err := b.buildIncidentBody(reqID, reqData)
@@ -198,12 +314,17 @@ func (b *Bot) CreateIncident(ctx context.Context, reqID string, reqData RequestD
 		return PagerdutyData{}, trace.Wrap(err)
 	}
 
-	incident, err := client.CreateIncident(b.from, &pd.CreateIncidentOptions{
+	from := target.From
+	if from == "" {
+		from = b.from
+	}
+
+	incident, err := client.CreateIncident(from, &pd.CreateIncidentOptions{
 		Title:       fmt.Sprintf("Access request from %s", reqData.User),
 		IncidentKey: fmt.Sprintf("%s/%s", pdIncidentKeyPrefix, reqID),
 		Service: &pd.APIReference{
 			Type: "service_reference",
-			ID:   b.serviceID,
+			ID:   target.ServiceID,
 		},
 		Body: &pd.APIDetails{
 			Type:    "incident_body",
@@ -213,32 +334,149 @@ func (b *Bot) CreateIncident(ctx context.Context, reqID string, reqData RequestD
 	if err != nil {
 		return PagerdutyData{}, trace.Wrap(err)
 	}
+	metricIncidentsCreated.Inc()
 
 	return PagerdutyData{
-		ID: incident.Id, // Yes, due to strange implementation, it's called `Id` which overrides `APIObject.ID`.
+		ID:        incident.Id, // Yes, due to strange implementation, it's called `Id` which overrides `APIObject.ID`.
+		ServiceID: target.ServiceID,
 	}, nil
 }
 
-func (b *Bot) ResolveIncident(ctx context.Context, reqID string, pdData PagerdutyData, status string) error {
+// ResolveIncident resolves the PagerDuty incident recorded in pdData,
+// leaving a note explaining status, and publishes a lifecycle event of the
+// given kind attributed to actor. target identifies the route the incident
+// was created against; its From (falling back to b.from) is used as the
+// acting PagerDuty identity, so resolving an incident on a routed service
+// acts as that route's configured identity rather than the bot's default.
+func (b *Bot) ResolveIncident(ctx context.Context, reqID string, pdData PagerdutyData, target RouteTarget, status string, kind events.Kind, actor string) error {
 	client := b.NewClient(ctx)
 
+	from := target.From
+	if from == "" {
+		from = b.from
+	}
+
 	err := client.CreateIncidentNote(pdData.ID, pd.IncidentNote{
 		User: pd.APIObject{
-			Summary: b.from,
+			Summary: from,
 		},
 		Content: fmt.Sprintf("Access request has been %s", status),
 	})
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	_, err = client.ManageIncidents(b.from, []pd.ManageIncidentsOptions{
+	_, err = client.ManageIncidents(from, []pd.ManageIncidentsOptions{
 		pd.ManageIncidentsOptions{
 			ID:     pdData.ID,
 			Type:   "incident_reference",
 			Status: "resolved",
 		},
 	})
-	return trace.Wrap(err)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	metricIncidentsResolved.WithLabelValues(resolutionLabel(kind)).Inc()
+
+	if pubErr := b.events.Publish(ctx, events.Event{
+		Kind:       kind,
+		RequestID:  reqID,
+		IncidentID: pdData.ID,
+		Actor:      actor,
+		Time:       time.Now(),
+	}); pubErr != nil {
+		log.WithError(pubErr).WithField("request_id", reqID).Warn("Failed to publish lifecycle event")
+	}
+
+	return nil
+}
+
+// IsUserOnCall reports whether the PagerDuty user with the given email is
+// currently on-call (or about to come on-call within autoApproval.Lookahead)
+// on one of the configured schedules or escalation policies.
+func (b *Bot) IsUserOnCall(ctx context.Context, email string) (bool, error) {
+	client := b.NewClient(ctx)
+
+	usersResp, err := client.ListUsers(pd.ListUsersOptions{
+		APIListObject: pd.APIListObject{Limit: pdListLimit},
+		Query:         email,
+	})
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+
+	var userID string
+	for _, user := range usersResp.Users {
+		if strings.EqualFold(user.Email, email) {
+			userID = user.ID
+			break
+		}
+	}
+	if userID == "" {
+		return false, trace.NotFound("no PagerDuty user found for email %q", email)
+	}
+
+	since := time.Now()
+	until := since.Add(b.autoApproval.Lookahead)
+
+	var more bool
+	var offset uint
+	for offset, more = 0, true; more; {
+		onCallsResp, err := client.ListOnCalls(pd.ListOnCallOptions{
+			APIListObject:       pd.APIListObject{Offset: offset, Limit: pdListLimit},
+			UserIDs:             []string{userID},
+			ScheduleIDs:         b.autoApproval.ScheduleIDs,
+			EscalationPolicyIDs: b.autoApproval.EscalationPolicyIDs,
+			Since:               since.Format(time.RFC3339),
+			Until:               until.Format(time.RFC3339),
+		})
+		if err != nil {
+			return false, trace.Wrap(err)
+		}
+		if len(onCallsResp.OnCalls) > 0 {
+			return true, nil
+		}
+
+		more = onCallsResp.More
+		offset += pdListLimit
+	}
+
+	return false, nil
+}
+
+// ListOpenIncidentsByRequest lists every open (triggered/acknowledged)
+// incident on serviceIDs that was created by this plugin (i.e. its
+// IncidentKey is prefixed with pdIncidentKeyPrefix), keyed by the Teleport
+// access request ID it belongs to.
+func (b *Bot) ListOpenIncidentsByRequest(ctx context.Context, serviceIDs []string) (map[string]PagerdutyData, error) {
+	client := b.NewClient(ctx)
+
+	result := make(map[string]PagerdutyData)
+
+	var more bool
+	var offset uint
+	for offset, more = 0, true; more; {
+		resp, err := client.ListIncidents(pd.ListIncidentsOptions{
+			APIListObject: pd.APIListObject{Offset: offset, Limit: pdListLimit},
+			ServiceIDs:    serviceIDs,
+			Statuses:      []string{"triggered", "acknowledged"},
+		})
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+
+		for _, incident := range resp.Incidents {
+			keyParts := strings.Split(incident.IncidentKey, "/")
+			if len(keyParts) != 2 || keyParts[0] != pdIncidentKeyPrefix {
+				continue
+			}
+			result[keyParts[1]] = PagerdutyData{ID: incident.Id, ServiceID: incident.Service.ID}
+		}
+
+		more = resp.More
+		offset += pdListLimit
+	}
+
+	return result, nil
 }
 
 func (b *Bot) GetUserInfo(ctx context.Context, userID string) (*pd.User, error) {
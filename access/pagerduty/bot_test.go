@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestNormalizeAPIPath(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{path: "/incidents", want: "/incidents"},
+		{path: "/incidents/PXXXXXX", want: "/incidents/{id}"},
+		{path: "/incidents/PXXXXXX/notes", want: "/incidents/{id}/notes"},
+		{path: "/extensions/PYYYYYY", want: "/extensions/{id}"},
+		{path: "/services/PZZZZZZ", want: "/services/{id}"},
+		{path: "/users/PUUUUUU", want: "/users/{id}"},
+		{path: "/oncalls", want: "/oncalls"},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			if got := normalizeAPIPath(c.path); got != c.want {
+				t.Errorf("normalizeAPIPath(%q) = %q, want %q", c.path, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import "time"
+
+// Config is the PagerDuty plugin's TOML configuration.
+type Config struct {
+	Teleport  TeleportConfig  `toml:"teleport"`
+	Pagerduty PagerdutyConfig `toml:"pagerduty"`
+	HTTP      HTTPConfig      `toml:"http"`
+
+	// AutoApproval configures on-call-aware auto-approval, see
+	// AutoApprovalConfig.
+	AutoApproval AutoApprovalConfig `toml:"auto_approval"`
+
+	// ServiceRoutes maps Teleport roles to PagerDuty services, see
+	// ServiceRoute.
+	ServiceRoutes []ServiceRoute `toml:"service_route"`
+
+	// Reconciler configures the startup/periodic incident reconciliation
+	// pass, see ReconcilerConfig.
+	Reconciler ReconcilerConfig `toml:"reconciler"`
+
+	// Events configures the lifecycle event sinks, see EventsConfig.
+	Events EventsConfig `toml:"events"`
+
+	// Health configures the /healthz endpoint, see HealthConfig.
+	Health HealthConfig `toml:"health"`
+}
+
+// EventsConfig configures the lifecycle event sinks built in
+// App.buildEventSink, see [events] in the plugin's TOML config.
+type EventsConfig struct {
+	File    EventsFileConfig    `toml:"file"`
+	Webhook EventsWebhookConfig `toml:"webhook"`
+	// Stdout, when true, additionally logs every event as a line of JSON
+	// on stdout.
+	Stdout bool `toml:"stdout"`
+	// QueueSize bounds the FanOut's event queue. An unset or non-positive
+	// value falls back to a built-in default.
+	QueueSize int `toml:"queue_size"`
+}
+
+// EventsFileConfig configures the JSON-lines file sink. An empty Path
+// disables it.
+type EventsFileConfig struct {
+	Path string `toml:"path"`
+}
+
+// EventsWebhookConfig configures the outbound, HMAC-signed webhook sink. An
+// empty URL disables it.
+type EventsWebhookConfig struct {
+	URL    string `toml:"url"`
+	Secret string `toml:"secret"`
+}
+
+// ReconcilerConfig configures the Reconciler, see [reconciler] in the
+// plugin's TOML config.
+type ReconcilerConfig struct {
+	// Interval is how often Reconciler.Run repeats after its initial,
+	// startup pass. An unset or non-positive value falls back to
+	// reconcileDefaultInterval.
+	Interval time.Duration `toml:"interval"`
+}
+
+// HealthConfig configures the /healthz endpoint, see [health] in the
+// plugin's TOML config.
+type HealthConfig struct {
+	// Freshness is how stale App.healthzHandler allows the last PagerDuty
+	// API health check to be before failing the probe. An unset or
+	// non-positive value falls back to healthFreshnessDefault.
+	Freshness time.Duration `toml:"freshness"`
+}
+
+// TeleportConfig carries the plugin's credentials for connecting to the
+// Teleport auth server.
+type TeleportConfig struct {
+	AuthServer string `toml:"auth_server"`
+	ClientCrt  string `toml:"client_crt"`
+	ClientKey  string `toml:"client_key"`
+	RootCAs    string `toml:"root_cas"`
+}
+
+// PagerdutyConfig carries the plugin's PagerDuty API credentials and
+// default service.
+type PagerdutyConfig struct {
+	APIEndpoint string `toml:"api_endpoint"`
+	APIKey      string `toml:"api_key"`
+	UserEmail   string `toml:"user_email"`
+	ServiceID   string `toml:"service_id"`
+
+	// WebhookSigningSecrets verifies the X-PagerDuty-Signature header on
+	// inbound webhook actions, see verifyWebhookSignature. An empty list
+	// skips verification, so existing deployments keep working until they
+	// opt in.
+	WebhookSigningSecrets []string `toml:"webhook_signing_secrets"`
+}
+
+// HTTPConfig configures the plugin's inbound webhook listener.
+type HTTPConfig struct {
+	ListenAddr string `toml:"listen_addr"`
+	PublicAddr string `toml:"public_addr"`
+	KeyFile    string `toml:"https_key_file"`
+	CertFile   string `toml:"https_cert_file"`
+	Insecure   bool   `toml:"insecure_no_tls"`
+
+	// AdminListenAddr, if set, serves /reconcile and /metrics on their own
+	// internal-only listener instead of the public webhook port. Those
+	// endpoints can trigger real PagerDuty API calls and are not
+	// protected by the webhook signature check, so this should be set to
+	// an address reachable only from inside the cluster/network in any
+	// production deployment.
+	AdminListenAddr string `toml:"admin_listen_addr"`
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestData contains the access request fields that need to be stored as
+// PluginData so they're still available to code that only has a request
+// ID (e.g. webhook callbacks, the reconciler).
+type RequestData struct {
+	User    string
+	Roles   []string
+	Created time.Time
+}
+
+// PagerdutyData identifies the PagerDuty incident created for an access
+// request, including the service it was filed against so that later code
+// (action callbacks, expirations, the reconciler) can resolve the correct
+// RouteTarget without having to re-derive it from the request's roles.
+type PagerdutyData struct {
+	ID        string
+	ServiceID string
+}
+
+// PluginData is the full set of plugin state stored against a Teleport
+// access request.
+type PluginData struct {
+	RequestData
+	PagerdutyData
+}
+
+// EncodePluginData serializes a PluginData into the string map format
+// understood by access.Client's plugin data API.
+func EncodePluginData(data PluginData) map[string]string {
+	result := make(map[string]string)
+
+	result["user"] = data.User
+	result["roles"] = strings.Join(data.Roles, ",")
+	if !data.Created.IsZero() {
+		result["created"] = strconv.FormatInt(data.Created.Unix(), 10)
+	}
+	result["incident_id"] = data.ID
+	result["service_id"] = data.ServiceID
+
+	return result
+}
+
+// DecodePluginData deserializes a PluginData from the string map format
+// understood by access.Client's plugin data API. Unknown or malformed
+// fields are left at their zero value.
+func DecodePluginData(dataMap map[string]string) PluginData {
+	var data PluginData
+
+	data.User = dataMap["user"]
+	if roles := dataMap["roles"]; roles != "" {
+		data.Roles = strings.Split(roles, ",")
+	}
+	if created, err := strconv.ParseInt(dataMap["created"], 10, 64); err == nil {
+		data.Created = time.Unix(created, 0)
+	}
+	data.ID = dataMap["incident_id"]
+	data.ServiceID = dataMap["service_id"]
+
+	return data
+}
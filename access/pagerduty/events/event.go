@@ -0,0 +1,49 @@
+// Package events defines the PagerDuty plugin's lifecycle event model and
+// the pluggable sinks that can receive it.
+package events
+
+import (
+	"context"
+	"time"
+)
+
+// Kind identifies the kind of lifecycle event published by the plugin.
+type Kind string
+
+const (
+	KindRequestPending  Kind = "RequestPending"
+	KindIncidentCreated Kind = "IncidentCreated"
+	KindRequestApproved Kind = "RequestApproved"
+	KindRequestDenied   Kind = "RequestDenied"
+	KindRequestExpired  Kind = "RequestExpired"
+	KindReconcileDrift  Kind = "ReconcileDrift"
+)
+
+// Event is a single, strongly-typed lifecycle event emitted as the
+// PagerDuty plugin processes access requests.
+type Event struct {
+	Kind Kind `json:"kind"`
+
+	RequestID  string   `json:"request_id"`
+	User       string   `json:"user"`
+	Roles      []string `json:"roles,omitempty"`
+	IncidentID string   `json:"incident_id,omitempty"`
+
+	// Actor identifies who/what caused the event, e.g. the requesting
+	// Teleport user, "pagerduty-webhook", "auto-approval", or "reconciler".
+	Actor string    `json:"actor,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// EventSink publishes Events to some destination. Publish must not block
+// the caller for long; sinks doing slow I/O should buffer internally (see
+// FanOut).
+type EventSink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NoopSink discards every event. It is the zero-configuration default when
+// no sinks are set up in TOML.
+type NoopSink struct{}
+
+func (NoopSink) Publish(ctx context.Context, event Event) error { return nil }
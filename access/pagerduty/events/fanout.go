@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FanOut delivers Events to a set of sinks from a single background
+// goroutine fed by a bounded channel, so Publish never blocks request
+// handling. Once the queue is full, further events are dropped and counted
+// rather than blocking the caller.
+type FanOut struct {
+	sinks   []EventSink
+	queue   chan Event
+	dropped uint64
+}
+
+// NewFanOut creates a FanOut with the given queue depth and starts its
+// delivery goroutine. Call Close once no more events will be published.
+func NewFanOut(queueSize int, sinks ...EventSink) *FanOut {
+	f := &FanOut{
+		sinks: sinks,
+		queue: make(chan Event, queueSize),
+	}
+	go f.run()
+	return f
+}
+
+// Publish enqueues event for delivery to every sink. It never blocks: if the
+// queue is full, the event is dropped and Dropped() is incremented.
+func (f *FanOut) Publish(ctx context.Context, event Event) error {
+	select {
+	case f.queue <- event:
+	default:
+		atomic.AddUint64(&f.dropped, 1)
+		log.WithField("kind", event.Kind).WithField("request_id", event.RequestID).
+			Warn("Event queue full, dropping event")
+	}
+	return nil
+}
+
+// Dropped returns the number of events dropped so far due to a full queue.
+func (f *FanOut) Dropped() uint64 {
+	return atomic.LoadUint64(&f.dropped)
+}
+
+// Close stops accepting new events; the delivery goroutine still delivers
+// the events already queued and then exits. Close does not wait for that,
+// and Publish must not be called after Close.
+func (f *FanOut) Close() {
+	close(f.queue)
+}
+
+func (f *FanOut) run() {
+	for event := range f.queue {
+		for _, sink := range f.sinks {
+			if err := sink.Publish(context.Background(), event); err != nil {
+				log.WithError(err).WithField("kind", event.Kind).Warn("Failed to publish event")
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingSink blocks every Publish call until release is closed, so
+// FanOut's delivery goroutine can be held busy on the first event while the
+// test fills the rest of the queue.
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Publish(ctx context.Context, event Event) error {
+	<-s.release
+	return nil
+}
+
+func TestFanOutDropsWhenQueueFull(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	f := NewFanOut(2, sink)
+	defer func() {
+		close(sink.release)
+		f.Close()
+	}()
+
+	// Capacity is 2, and the delivery goroutine will pick up the first
+	// event and block on the sink, so publishing 5 events in a row can
+	// leave at most 3 in flight (one being delivered plus a full queue of
+	// 2); the rest must be dropped, not block the caller.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			if err := f.Publish(context.Background(), Event{Kind: KindRequestPending}); err != nil {
+				t.Errorf("Publish: %v", err)
+			}
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked instead of dropping events once the queue filled up")
+	}
+
+	if dropped := f.Dropped(); dropped == 0 {
+		t.Fatal("expected Dropped() > 0 once the queue filled up")
+	}
+}
+
+// recordingSink records every event it receives, guarded by a mutex since
+// FanOut delivers from its own goroutine.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+func TestFanOutDeliversWithinCapacity(t *testing.T) {
+	recorder := &recordingSink{}
+	f := NewFanOut(4, recorder)
+	defer f.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := f.Publish(context.Background(), Event{Kind: KindIncidentCreated}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for recorder.count() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := recorder.count(); got != 3 {
+		t.Fatalf("got %d delivered events, want 3", got)
+	}
+	if dropped := f.Dropped(); dropped != 0 {
+		t.Fatalf("Dropped() = %d, want 0 when under capacity", dropped)
+	}
+}
@@ -0,0 +1,29 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gravitational/trace"
+)
+
+// StdoutSink writes each Event as a line of JSON to w, for local debugging.
+type StdoutSink struct {
+	w io.Writer
+}
+
+// NewStdoutSink creates a StdoutSink writing to w (typically os.Stdout).
+func NewStdoutSink(w io.Writer) *StdoutSink {
+	return &StdoutSink{w: w}
+}
+
+func (s *StdoutSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = fmt.Fprintln(s.w, string(body))
+	return trace.Wrap(err)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/gravitational/teleport-plugins/access/pagerduty/events"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	metricIncidentsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "incidents_created_total",
+		Help:      "Number of PagerDuty incidents created by the plugin.",
+	})
+
+	metricIncidentsResolved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "incidents_resolved_total",
+		Help:      "Number of PagerDuty incidents resolved by the plugin, by resolution.",
+	}, []string{"resolution"})
+
+	metricPagerdutyLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of calls to the PagerDuty API, by endpoint route template (see normalizeAPIPath).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	metricPagerdutyErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "api_errors_total",
+		Help:      "Number of PagerDuty API calls that returned an error, by status code.",
+	}, []string{"status_code"})
+
+	metricWebhookActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "webhook_actions_total",
+		Help:      "Number of inbound PagerDuty webhook actions, by action and outcome.",
+	}, []string{"action", "outcome"})
+
+	metricPendingRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "pending_requests",
+		Help:      "Number of Teleport access requests currently pending, as last observed by the reconciler.",
+	})
+
+	metricSignatureFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "teleport",
+		Subsystem: "pagerduty",
+		Name:      "webhook_signature_failures_total",
+		Help:      "Number of inbound PagerDuty webhook actions rejected for an invalid or missing signature.",
+	})
+)
+
+// resolutionLabel maps an events.Kind to the short resolution label used by
+// metricIncidentsResolved.
+func resolutionLabel(kind events.Kind) string {
+	switch kind {
+	case events.KindRequestApproved:
+		return "approved"
+	case events.KindRequestDenied:
+		return "denied"
+	case events.KindRequestExpired:
+		return "expired"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gravitational/teleport-plugins/access"
+	"github.com/gravitational/teleport-plugins/access/pagerduty/events"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reconcileDefaultInterval is used when [reconciler].interval is unset.
+const reconcileDefaultInterval = 5 * time.Minute
+
+// Reconciler periodically diffs pending Teleport access requests against
+// open PagerDuty incidents, repairing drift caused by the plugin missing
+// watcher events (e.g. while it was down). It runs once at startup and then
+// on a fixed interval, and can also be triggered on demand via the
+// /reconcile admin endpoint (on AdminServer, or on WebhookServer when no
+// admin listener is configured).
+type Reconciler struct {
+	accessClient access.Client
+	bot          *Bot
+	router       *ServiceRouter
+	events       events.EventSink
+	interval     time.Duration
+}
+
+// NewReconciler creates a Reconciler. An interval <= 0 falls back to
+// reconcileDefaultInterval.
+func NewReconciler(accessClient access.Client, bot *Bot, router *ServiceRouter, sink events.EventSink, interval time.Duration) *Reconciler {
+	if interval <= 0 {
+		interval = reconcileDefaultInterval
+	}
+	return &Reconciler{
+		accessClient: accessClient,
+		bot:          bot,
+		router:       router,
+		events:       sink,
+		interval:     interval,
+	}
+}
+
+// publishDrift reports a piece of drift that Reconcile found and repaired.
+func (r *Reconciler) publishDrift(ctx context.Context, reqID, incidentID string) {
+	if err := r.events.Publish(ctx, events.Event{
+		Kind:       events.KindReconcileDrift,
+		RequestID:  reqID,
+		IncidentID: incidentID,
+		Actor:      "reconciler",
+		Time:       time.Now(),
+	}); err != nil {
+		log.WithError(err).WithField("request_id", reqID).Warn("Failed to publish reconcile-drift event")
+	}
+}
+
+// Run reconciles once immediately and then again every r.interval, until ctx
+// is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	if err := r.Reconcile(ctx); err != nil {
+		log.WithError(err).Error("Initial reconciliation failed")
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Reconcile(ctx); err != nil {
+				log.WithError(err).Error("Periodic reconciliation failed")
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Reconcile lists pending access requests and open PagerDuty incidents and
+// repairs any drift between them: it creates incidents for pending requests
+// that have none, resolves incidents whose request is gone, and refreshes
+// PluginData when the recorded incident ID no longer matches reality.
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	requests, err := r.accessClient.GetRequests(ctx, access.Filter{State: access.StatePending})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	serviceIDs := r.serviceIDs()
+	openIncidents, err := r.bot.ListOpenIncidentsByRequest(ctx, serviceIDs)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	metricPendingRequests.Set(float64(len(requests)))
+
+	pending := make(map[string]bool, len(requests))
+	for _, req := range requests {
+		pending[req.ID] = true
+		if err := r.reconcilePending(ctx, req, openIncidents[req.ID]); err != nil {
+			log.WithError(err).WithField("request_id", req.ID).Error("Failed to reconcile pending request")
+		}
+	}
+
+	for reqID, incident := range openIncidents {
+		if pending[reqID] {
+			continue
+		}
+		target := r.resolveTargetForService(incident.ServiceID)
+		if err := r.bot.ResolveIncident(ctx, reqID, incident, target, "expired",
+			events.KindRequestExpired, "reconciler"); err != nil {
+			log.WithError(err).WithField("request_id", reqID).Error("Failed to resolve orphan incident")
+			continue
+		}
+		r.publishDrift(ctx, reqID, incident.ID)
+		log.WithField("request_id", reqID).Warn("Resolved orphan PagerDuty incident with no matching pending request")
+	}
+
+	return nil
+}
+
+// reconcilePending ensures a single pending request has a PagerDuty incident
+// and that its PluginData agrees with what PagerDuty actually has.
+func (r *Reconciler) reconcilePending(ctx context.Context, req access.Request, liveIncident PagerdutyData) error {
+	reqData := RequestData{User: req.User, Roles: req.Roles, Created: req.Created}
+
+	pluginData, err := r.getPluginData(ctx, req.ID)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if liveIncident.ID == "" {
+		target := r.resolveTarget(req.Roles)
+		pdData, err := r.bot.CreateIncident(ctx, req.ID, reqData, target)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		log.WithField("request_id", req.ID).Warn("Created missing PagerDuty incident during reconciliation")
+		r.publishDrift(ctx, req.ID, pdData.ID)
+		return trace.Wrap(r.setPluginData(ctx, req.ID, PluginData{reqData, pdData}))
+	}
+
+	if pluginData.PagerdutyData.ID != liveIncident.ID {
+		log.WithField("request_id", req.ID).Warn("Refreshing PluginData to match live PagerDuty incident")
+		r.publishDrift(ctx, req.ID, liveIncident.ID)
+		return trace.Wrap(r.setPluginData(ctx, req.ID, PluginData{reqData, liveIncident}))
+	}
+
+	return nil
+}
+
+func (r *Reconciler) serviceIDs() []string {
+	var serviceIDs []string
+	for _, target := range r.router.Targets() {
+		serviceIDs = append(serviceIDs, target.ServiceID)
+	}
+	serviceIDs = append(serviceIDs, r.bot.defaultTarget().ServiceID)
+	return serviceIDs
+}
+
+func (r *Reconciler) resolveTarget(reqRoles []string) RouteTarget {
+	if target, ok := r.router.Route(reqRoles); ok {
+		return target
+	}
+	return r.bot.defaultTarget()
+}
+
+// resolveTargetForService mirrors App.resolveTargetForService, recovering
+// the RouteTarget an existing incident belongs to from its ServiceID alone.
+func (r *Reconciler) resolveTargetForService(serviceID string) RouteTarget {
+	if target, ok := r.router.TargetForService(serviceID); ok {
+		return target
+	}
+	return r.bot.defaultTarget()
+}
+
+func (r *Reconciler) getPluginData(ctx context.Context, reqID string) (PluginData, error) {
+	dataMap, err := r.accessClient.GetPluginData(ctx, reqID)
+	if err != nil {
+		return PluginData{}, trace.Wrap(err)
+	}
+	return DecodePluginData(dataMap), nil
+}
+
+func (r *Reconciler) setPluginData(ctx context.Context, reqID string, data PluginData) error {
+	return r.accessClient.UpdatePluginData(ctx, reqID, EncodePluginData(data), nil)
+}
+
+// AdminHandler is an http.HandlerFunc registered as the "/reconcile" admin
+// endpoint (see App.run), triggering an immediate,
+// synchronous reconciliation pass.
+func (r *Reconciler) AdminHandler(w http.ResponseWriter, req *http.Request) {
+	if err := r.Reconcile(req.Context()); err != nil {
+		log.WithError(err).Error("On-demand reconciliation failed")
+		http.Error(w, trace.UserMessage(err), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("reconciled\n"))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"github.com/gravitational/trace"
+)
+
+// ServiceRoute maps a set of Teleport roles to a PagerDuty routing target,
+// configured via a `[[service_route]]` TOML section.
+type ServiceRoute struct {
+	// MatchRoles lists the Teleport role names this route applies to. A
+	// request is routed here if it carries any of these roles.
+	MatchRoles []string `toml:"match_roles"`
+	ServiceID  string   `toml:"service_id"`
+	// From overrides the PagerdutyConfig.UserEmail used to create/resolve
+	// incidents on this route, if set.
+	From string `toml:"from"`
+	// EscalationPolicy, if set, is copied into the route's RouteTarget. It
+	// is not sent to the PagerDuty incidents API and is not used by the
+	// auto-approval on-call lookup.
+	EscalationPolicy string `toml:"escalation_policy"`
+	// Default marks the route used when no MatchRoles match a request. At
+	// most one route may set this.
+	Default bool `toml:"default"`
+}
+
+// RouteTarget identifies the PagerDuty service (and associated overrides)
+// an incident should be created against or resolved on.
+type RouteTarget struct {
+	ServiceID        string
+	From             string
+	EscalationPolicy string
+}
+
+// ServiceRouter resolves which PagerDuty service an access request's
+// incident should be created against, based on the request's roles.
+type ServiceRouter struct {
+	routes []ServiceRoute
+	dfault *ServiceRoute
+}
+
+// NewServiceRouter builds a ServiceRouter from the `[[service_route]]`
+// config sections. It is an error for more than one route to be marked
+// default.
+func NewServiceRouter(routes []ServiceRoute) (*ServiceRouter, error) {
+	router := &ServiceRouter{routes: routes}
+	for i, route := range routes {
+		if !route.Default {
+			continue
+		}
+		if router.dfault != nil {
+			return nil, trace.BadParameter("at most one service_route may set default = true")
+		}
+		router.dfault = &routes[i]
+	}
+	return router, nil
+}
+
+// Route returns the target that an access request carrying reqRoles should
+// be routed to. Routes are matched in configuration order; the default
+// route (if any) is used when nothing matches. It returns false if there is
+// no matching route and no default.
+func (r *ServiceRouter) Route(reqRoles []string) (RouteTarget, bool) {
+	for _, route := range r.routes {
+		if route.Default {
+			continue
+		}
+		if rolesIntersect(route.MatchRoles, reqRoles) {
+			return routeTarget(route), true
+		}
+	}
+	if r.dfault != nil {
+		return routeTarget(*r.dfault), true
+	}
+	return RouteTarget{}, false
+}
+
+// TargetForService returns the RouteTarget whose ServiceID matches
+// serviceID, so callers that only have a PagerdutyData (e.g. a webhook
+// callback or the reconciler resolving an orphan incident) can recover the
+// route's configured From without needing the original request's roles.
+func (r *ServiceRouter) TargetForService(serviceID string) (RouteTarget, bool) {
+	for _, route := range r.routes {
+		if route.ServiceID == serviceID {
+			return routeTarget(route), true
+		}
+	}
+	return RouteTarget{}, false
+}
+
+// Targets returns every distinct PagerDuty service configured across all
+// routes, used by Bot.Setup to install custom actions everywhere.
+func (r *ServiceRouter) Targets() []RouteTarget {
+	seen := make(map[string]bool)
+	var targets []RouteTarget
+	for _, route := range r.routes {
+		target := routeTarget(route)
+		if seen[target.ServiceID] {
+			continue
+		}
+		seen[target.ServiceID] = true
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+func routeTarget(route ServiceRoute) RouteTarget {
+	return RouteTarget{
+		ServiceID:        route.ServiceID,
+		From:             route.From,
+		EscalationPolicy: route.EscalationPolicy,
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestServiceRouterRoute(t *testing.T) {
+	router, err := NewServiceRouter([]ServiceRoute{
+		{MatchRoles: []string{"dba"}, ServiceID: "dba-service", From: "dba@example.com"},
+		{MatchRoles: []string{"sre"}, ServiceID: "sre-service"},
+		{Default: true, ServiceID: "default-service"},
+	})
+	if err != nil {
+		t.Fatalf("NewServiceRouter: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		roles    []string
+		wantID   string
+		wantOk   bool
+		wantFrom string
+	}{
+		{name: "matches first route", roles: []string{"dba"}, wantID: "dba-service", wantOk: true, wantFrom: "dba@example.com"},
+		{name: "matches later route", roles: []string{"auditor", "sre"}, wantID: "sre-service", wantOk: true},
+		{name: "falls back to default", roles: []string{"auditor"}, wantID: "default-service", wantOk: true},
+		{name: "no roles falls back to default", roles: nil, wantID: "default-service", wantOk: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target, ok := router.Route(c.roles)
+			if ok != c.wantOk {
+				t.Fatalf("Route(%v) ok = %v, want %v", c.roles, ok, c.wantOk)
+			}
+			if target.ServiceID != c.wantID {
+				t.Errorf("Route(%v) ServiceID = %q, want %q", c.roles, target.ServiceID, c.wantID)
+			}
+			if target.From != c.wantFrom {
+				t.Errorf("Route(%v) From = %q, want %q", c.roles, target.From, c.wantFrom)
+			}
+		})
+	}
+}
+
+func TestServiceRouterRouteNoDefault(t *testing.T) {
+	router, err := NewServiceRouter([]ServiceRoute{
+		{MatchRoles: []string{"dba"}, ServiceID: "dba-service"},
+	})
+	if err != nil {
+		t.Fatalf("NewServiceRouter: %v", err)
+	}
+
+	if _, ok := router.Route([]string{"auditor"}); ok {
+		t.Fatal("Route() with no matching role and no default route should return ok = false")
+	}
+}
+
+func TestServiceRouterRejectsMultipleDefaults(t *testing.T) {
+	_, err := NewServiceRouter([]ServiceRoute{
+		{Default: true, ServiceID: "a"},
+		{Default: true, ServiceID: "b"},
+	})
+	if err == nil {
+		t.Fatal("NewServiceRouter with two default routes should error")
+	}
+}
+
+func TestServiceRouterTargetForService(t *testing.T) {
+	router, err := NewServiceRouter([]ServiceRoute{
+		{MatchRoles: []string{"dba"}, ServiceID: "dba-service", From: "dba@example.com"},
+		{Default: true, ServiceID: "default-service"},
+	})
+	if err != nil {
+		t.Fatalf("NewServiceRouter: %v", err)
+	}
+
+	target, ok := router.TargetForService("dba-service")
+	if !ok {
+		t.Fatal("TargetForService(dba-service) ok = false, want true")
+	}
+	if target.From != "dba@example.com" {
+		t.Errorf("TargetForService(dba-service) From = %q, want %q", target.From, "dba@example.com")
+	}
+
+	if _, ok := router.TargetForService("unknown-service"); ok {
+		t.Fatal("TargetForService(unknown-service) ok = true, want false")
+	}
+}
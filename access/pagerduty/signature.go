@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// webhookSignaturePrefix is the version prefix PagerDuty (and our own
+// outbound events.WebhookSink) uses for HMAC-SHA256 webhook signatures.
+const webhookSignaturePrefix = "v1="
+
+// verifyWebhookSignature reports whether header, the raw value of the
+// inbound X-PagerDuty-Signature header, contains a valid signature of body
+// for at least one of secrets. PagerDuty signs a webhook with every secret
+// currently configured for its destination, so header may carry more than
+// one comma-separated "v1=<hex>" value, and we accept a match against any
+// configured secret. If no secrets are configured, verification is skipped
+// so existing deployments keep working until they opt in.
+func verifyWebhookSignature(secrets []string, header string, body []byte) bool {
+	if len(secrets) == 0 {
+		return true
+	}
+	for _, field := range strings.Split(header, ",") {
+		field = strings.TrimSpace(field)
+		if !strings.HasPrefix(field, webhookSignaturePrefix) {
+			continue
+		}
+		want, err := hex.DecodeString(strings.TrimPrefix(field, webhookSignaturePrefix))
+		if err != nil {
+			continue
+		}
+		for _, secret := range secrets {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			if hmac.Equal(mac.Sum(nil), want) {
+				return true
+			}
+		}
+	}
+	return false
+}
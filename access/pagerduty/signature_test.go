@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"incident.custom"}`)
+
+	t.Run("no secrets configured skips verification", func(t *testing.T) {
+		if !verifyWebhookSignature(nil, "", body) {
+			t.Fatal("expected verification to be skipped with no secrets configured")
+		}
+	})
+
+	t.Run("valid signature", func(t *testing.T) {
+		header := sign("s3cret", body)
+		if !verifyWebhookSignature([]string{"s3cret"}, header, body) {
+			t.Fatal("expected a signature matching the configured secret to verify")
+		}
+	})
+
+	t.Run("matches any configured secret", func(t *testing.T) {
+		header := sign("second-secret", body)
+		if !verifyWebhookSignature([]string{"first-secret", "second-secret"}, header, body) {
+			t.Fatal("expected a signature matching any configured secret to verify")
+		}
+	})
+
+	t.Run("accepts multiple comma-separated values", func(t *testing.T) {
+		header := sign("wrong", body) + ", " + sign("s3cret", body)
+		if !verifyWebhookSignature([]string{"s3cret"}, header, body) {
+			t.Fatal("expected verification to succeed on any comma-separated value")
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		header := sign("wrong-secret", body)
+		if verifyWebhookSignature([]string{"s3cret"}, header, body) {
+			t.Fatal("expected verification to fail for a signature from an unconfigured secret")
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		header := sign("s3cret", body)
+		if verifyWebhookSignature([]string{"s3cret"}, header, []byte(`{"event":"tampered"}`)) {
+			t.Fatal("expected verification to fail when the body doesn't match the signature")
+		}
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		if verifyWebhookSignature([]string{"s3cret"}, "", body) {
+			t.Fatal("expected verification to fail with a missing signature when secrets are configured")
+		}
+	})
+
+	t.Run("malformed header", func(t *testing.T) {
+		if verifyWebhookSignature([]string{"s3cret"}, "not-a-valid-signature", body) {
+			t.Fatal("expected verification to fail for a malformed signature header")
+		}
+	})
+}
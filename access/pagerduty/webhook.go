@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gravitational/teleport-plugins/utils"
+
+	"github.com/gravitational/trace"
+)
+
+// WebhookAction is the decoded, routed form of an inbound PagerDuty custom
+// incident action callback.
+type WebhookAction struct {
+	// Name is the action that was triggered, e.g. "approve" or "deny". It
+	// comes from which of the per-action URLs (see ActionURL) PagerDuty
+	// posted to, not from the request body.
+	Name string
+	// Event is the PagerDuty webhook event type, e.g. "incident.custom".
+	Event string
+	// IncidentID and IncidentKey identify the PagerDuty incident the
+	// action was triggered on.
+	IncidentID  string
+	IncidentKey string
+	// HTTPRequestID and MessageID are PagerDuty's own tracing identifiers
+	// for this delivery, logged to aid debugging.
+	HTTPRequestID string
+	MessageID     string
+	// Signature is the raw, unparsed value of the inbound
+	// X-PagerDuty-Signature header.
+	Signature string
+	// RawBody is the exact bytes of the request body, preserved for
+	// signature verification (which must run over the bytes PagerDuty
+	// actually signed, not a re-marshaled copy).
+	RawBody []byte
+}
+
+// pdActionPayload is the subset of PagerDuty's custom incident action
+// webhook payload the plugin cares about.
+type pdActionPayload struct {
+	Incident struct {
+		ID          string `json:"id"`
+		IncidentKey string `json:"incident_key"`
+	} `json:"incident"`
+}
+
+// WebhookServer is the plugin's inbound HTTP(S) server: it serves the
+// per-action PagerDuty callback endpoints plus the operational endpoints
+// registered by App.run: /healthz always, and /metrics and /reconcile only
+// when no [http].admin_listen_addr is set (otherwise AdminServer has them).
+type WebhookServer struct {
+	conf     HTTPConfig
+	callback func(ctx context.Context, action WebhookAction) error
+
+	mux        *http.ServeMux
+	httpServer *http.Server
+	tlsConfig  *tls.Config
+	baseURL    *url.URL
+
+	job utils.ServiceJob
+}
+
+// NewWebhookServer creates a WebhookServer listening per conf, routing
+// decoded actions to callback.
+func NewWebhookServer(conf HTTPConfig, callback func(ctx context.Context, action WebhookAction) error) (*WebhookServer, error) {
+	if conf.ListenAddr == "" {
+		return nil, trace.BadParameter("[http].listen_addr must be set")
+	}
+
+	scheme := "https"
+	if conf.Insecure {
+		scheme = "http"
+	}
+	publicAddr := conf.PublicAddr
+	if publicAddr == "" {
+		publicAddr = conf.ListenAddr
+	}
+	baseURL, err := url.Parse(fmt.Sprintf("%s://%s", scheme, publicAddr))
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	s := &WebhookServer{
+		conf:     conf,
+		callback: callback,
+		mux:      http.NewServeMux(),
+		baseURL:  baseURL,
+	}
+	s.httpServer = &http.Server{Addr: conf.ListenAddr, Handler: s.mux}
+	s.job = utils.NewServiceJob(s.run)
+
+	s.mux.HandleFunc("/actions/"+pdApproveAction, s.actionHandler(pdApproveAction))
+	s.mux.HandleFunc("/actions/"+pdDenyAction, s.actionHandler(pdDenyAction))
+
+	return s, nil
+}
+
+// ServiceJob returns the utils.ServiceJob that serves the webhook server,
+// for the caller to spawn and wait on.
+func (s *WebhookServer) ServiceJob() utils.ServiceJob {
+	return s.job
+}
+
+// BaseURL returns the externally reachable base URL of the server, used to
+// build ActionURL and report the plugin's own public address.
+func (s *WebhookServer) BaseURL() *url.URL {
+	return s.baseURL
+}
+
+// ActionURL returns the per-action callback URL to register with PagerDuty
+// as a custom incident action's endpoint. PagerDuty posts to this exact URL
+// whenever the action is triggered, with no indication of which action it
+// was in the body, so the action name must come from the route.
+func (s *WebhookServer) ActionURL(action string) string {
+	u := *s.baseURL
+	u.Path = "/actions/" + action
+	return u.String()
+}
+
+// HandleFunc registers an additional handler on the server's mux, used by
+// App.run to add /healthz and, when no admin listener is configured,
+// /metrics and /reconcile.
+func (s *WebhookServer) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// EnsureCert makes sure the server has a TLS certificate to serve, loading
+// it from HTTPConfig if configured or generating a self-signed one
+// otherwise. It is a no-op if HTTPConfig.Insecure is set.
+func (s *WebhookServer) EnsureCert() error {
+	if s.conf.Insecure {
+		return nil
+	}
+	if s.conf.CertFile != "" && s.conf.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.conf.CertFile, s.conf.KeyFile)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		return nil
+	}
+
+	cert, err := generateSelfSignedCert()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	return nil
+}
+
+func generateSelfSignedCert() (tls.Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return tls.Certificate{}, trace.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "teleport-pagerduty-plugin"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, trace.Wrap(err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
+
+// run serves the webhook server until ctx is done, reporting ready once the
+// listener is up.
+func (s *WebhookServer) run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.conf.ListenAddr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if s.tlsConfig != nil {
+		ln = tls.NewListener(ln, s.tlsConfig)
+	}
+
+	s.job.SetReady(true)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.httpServer.Serve(ln)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return trace.Wrap(s.httpServer.Close())
+	case err := <-errCh:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+}
+
+// actionHandler returns the handler registered for a single action's URL.
+func (s *WebhookServer) actionHandler(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		var payload pdActionPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		webhookAction := WebhookAction{
+			Name:          action,
+			Event:         "incident.custom",
+			IncidentID:    payload.Incident.ID,
+			IncidentKey:   payload.Incident.IncidentKey,
+			HTTPRequestID: r.Header.Get("X-Request-Id"),
+			MessageID:     r.Header.Get("X-Webhook-Id"),
+			Signature:     r.Header.Get("X-PagerDuty-Signature"),
+			RawBody:       body,
+		}
+
+		if err := s.callback(r.Context(), webhookAction); err != nil {
+			status := http.StatusInternalServerError
+			if trace.IsAccessDenied(err) {
+				status = http.StatusUnauthorized
+			}
+			http.Error(w, trace.UserMessage(err), status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}